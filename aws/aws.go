@@ -11,6 +11,7 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 
@@ -19,27 +20,59 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+// DefaultTokenTTL is used when UploadMultipleFilesFromStream is called with
+// ttl <= 0.
+const DefaultTokenTTL = time.Hour * 24 * 7
+
 type S3Client struct {
 	Client       *minio.Client
 	BucketName   string
 	bucketExists bool // Add cache for bucket existence
-	tokenMap     map[string]FileInfo
+	tokenStore   TokenStore
+
+	defaultEncryption encryptionPolicy
+	defaultTags       tagPolicy
+
+	// Multipart manages resumable, chunked uploads for this bucket.
+	Multipart *MultipartSession
+
+	// Webhooks streams bucket notifications to registered webhook URLs and
+	// SSE clients.
+	Webhooks *WebhookDispatcher
 }
 
-func NewS3Client(endpoint, accessKeyID, secretAccessKey, bucketName string, useSSL bool) (*S3Client, error) {
-	minioClient, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+// NewS3Client creates a client and bucket as before. creds selects how the
+// client authenticates (see StaticV4Credentials, IAMCredentials, etc.).
+// region overrides automatic region lookup; pass "" to let minio-go detect
+// it. tokenStore may be nil, in which case a MemoryTokenStore with a
+// one-minute janitor is used.
+func NewS3Client(endpoint string, creds CredentialsProvider, region, bucketName string, useSSL bool, tokenStore TokenStore) (*S3Client, error) {
+	opts := &minio.Options{
+		Creds:  creds.Credentials(),
 		Secure: useSSL,
-		Region: os.Getenv("REGION"),
-	})
+		Region: region,
+	}
+
+	minioClient, err := minio.New(endpoint, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error creating MinIO client: %w", err) // Enhanced error message
 	}
 
+	if tokenStore == nil {
+		tokenStore = NewMemoryTokenStore(time.Minute)
+	}
+
+	coreClient, err := minio.NewCore(endpoint, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error creating MinIO core client: %w", err)
+	}
+
 	s3Client := &S3Client{
 		Client:     minioClient,
 		BucketName: bucketName,
-		tokenMap:   make(map[string]FileInfo),
+		tokenStore: tokenStore,
+		Multipart:  NewMultipartSession(coreClient, bucketName, 0, tokenStore),
+		Webhooks:   NewWebhookDispatcher(minioClient, bucketName),
 	}
 
 	exists, err := s3Client.Client.BucketExists(context.Background(), bucketName)
@@ -57,6 +90,10 @@ func NewS3Client(endpoint, accessKeyID, secretAccessKey, bucketName string, useS
 
 	s3Client.bucketExists = exists // Update cache
 
+	if info, found, err := tokenStore.Get(defaultTagsStoreKey); err == nil && found {
+		s3Client.defaultTags.tags = info.Tags
+	}
+
 	return s3Client, nil
 }
 
@@ -100,20 +137,31 @@ func (s *S3Client) ListBuckets() ([]minio.BucketInfo, error) {
 }
 
 type FileInfo struct {
-	FileName  string    `json:"file_name"`
-	ExpiredAt time.Time `json:"expired_at"`
+	FileName  string            `json:"file_name"`
+	ExpiredAt time.Time         `json:"expired_at"`
+	Tags      map[string]string `json:"tags,omitempty"`
+
+	// UploadID, PartSize, and Parts are set only for entries representing a
+	// MultipartSession (keyed by session ID instead of a download token) so
+	// received part ETags survive a process restart and a session can be
+	// resumed without losing progress. They are empty for download tokens.
+	UploadID string         `json:"upload_id,omitempty"`
+	PartSize int64          `json:"part_size,omitempty"`
+	Parts    map[int]string `json:"parts,omitempty"`
 }
 
 type ReqLinkResponse struct {
-	Status   int    `json:"status"`
-	Token    string `json:"token"`
-	FileName string `json:"file_name"` // เพิ่ม field FileName
-	URL      string `json:"url"`
+	Status   int               `json:"status"`
+	Token    string            `json:"token"`
+	FileName string            `json:"file_name"` // เพิ่ม field FileName
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers,omitempty"` // SSE-C customer-key headers to send with the GET
 }
 
 type GenerateURLResponse struct {
-	Status int    `json:"status"`
-	URL    string `json:"url"`
+	Status  int               `json:"status"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // SSE-C customer-key headers to send with the GET
 }
 
 func GenerateToken(secretToken, fileName string) (string, error) {
@@ -124,7 +172,35 @@ func GenerateToken(secretToken, fileName string) (string, error) {
 	return token, nil
 }
 
-func (s *S3Client) UploadMultipleFilesFromStream(files []*multipart.FileHeader, contentType string) ([]ReqLinkResponse, error) {
+// UploadMultipleFilesFromStream uploads each file and issues a download
+// token for it. ttl controls how long that token stays valid; pass <= 0 to
+// fall back to DefaultTokenTTL. enc controls server-side encryption; pass
+// EncryptionOptions{} to use the bucket's default policy (see
+// SetDefaultEncryptionPolicy). objectTags is attached to every uploaded file
+// and, if empty, falls back to the bucket's default tags (see
+// SetDefaultTags) so repeat uploads inherit them without the caller having
+// to resend the same tags every time. When objectTags is non-empty, it also
+// becomes the new default, persisted to the TokenStore, so the next re-upload
+// inherits it even across a process restart.
+func (s *S3Client) UploadMultipleFilesFromStream(files []*multipart.FileHeader, contentType string, ttl time.Duration, enc EncryptionOptions, objectTags map[string]string) ([]ReqLinkResponse, error) {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	enc = s.resolveEncryption(enc)
+	sse, err := enc.serverSide()
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption options: %w", err)
+	}
+
+	explicitTags := len(objectTags) > 0
+	objectTags = s.resolveTags(objectTags)
+	if explicitTags {
+		if err := s.SetDefaultTags(objectTags); err != nil {
+			return nil, fmt.Errorf("failed to persist default tags: %w", err)
+		}
+	}
+
 	responses := make([]ReqLinkResponse, 0, len(files)) // Preallocate slice for efficiency
 	secretToken := os.Getenv("SECRET_TOKEN")
 
@@ -146,43 +222,67 @@ func (s *S3Client) UploadMultipleFilesFromStream(files []*multipart.FileHeader,
 		defer info.Close()
 
 		size := file.Size
-		_, err = s.Client.PutObject(context.Background(), s.BucketName, newFileName, info, size, minio.PutObjectOptions{ContentType: contentType})
+		_, err = s.Client.PutObject(context.Background(), s.BucketName, newFileName, info, size, minio.PutObjectOptions{ContentType: contentType, ServerSideEncryption: sse})
 		if err != nil {
 			return nil, err // Return error immediately if upload fails
 		}
 
+		if len(objectTags) > 0 {
+			if err := s.PutObjectTags(newFileName, objectTags); err != nil {
+				return nil, fmt.Errorf("failed to tag object: %w", err)
+			}
+		}
+
 		// สร้าง Token โดยใช้ Secret Token และชื่อไฟล์
 		token, err := GenerateToken(secretToken, newFileName)
 		if err != nil {
 			return nil, err
 		}
 
-		// สร้าง Presigned URL
+		sseHeaders, err := enc.headers()
+		if err != nil {
+			return nil, err
+		}
+
+		// สร้าง Presigned URL; sseHeaders must be signed into the request
+		// (not just handed back alongside it), or S3 rejects the GET with
+		// SignatureDoesNotMatch for SSE-C objects.
 		expirationTime := time.Hour
-		presignedURL, err := s.Client.PresignedGetObject(context.Background(), s.BucketName, newFileName, expirationTime, nil)
+		presignedURL, err := s.presignGetURL(context.Background(), newFileName, expirationTime, nil, sseHeaders)
 		if err != nil {
 			return nil, err
 		}
 
-		// เก็บ Token และข้อมูลไฟล์ (ในหน่วยความจำ, หรือคุณสามารถปรับให้บันทึกลงฐานข้อมูลได้)
+		// เก็บ Token และข้อมูลไฟล์ผ่าน TokenStore (in-memory, BoltDB, Redis, ...)
 		fileInfo := FileInfo{
 			FileName:  newFileName,
-			ExpiredAt: time.Now().Add(time.Hour * 24 * 7),
+			ExpiredAt: time.Now().Add(ttl),
+			Tags:      objectTags,
+		}
+		if err := s.tokenStore.Put(token, fileInfo); err != nil {
+			return nil, err
 		}
-		s.tokenMap[token] = fileInfo
 
 		responses = append(responses, ReqLinkResponse{
 			Status:   http.StatusOK,
 			Token:    token,
 			FileName: newFileName,
 			URL:      presignedURL.String(),
+			Headers:  sseHeaders,
 		})
 	}
 
 	return responses, nil
 }
 
-func (s *S3Client) GenerateDownloadURLWithFileNameAndToken(fileName, token string) (GenerateURLResponse, error) {
+// GenerateDownloadURLWithFileNameAndToken validates token and returns a
+// presigned GET URL for fileName. enc must describe the encryption the
+// object was uploaded with; pass EncryptionOptions{} for unencrypted or
+// SSE-S3/SSE-KMS objects (those don't require per-request headers), and the
+// matching CustomerKey for SSE-C objects. versionID presigns a specific
+// object version (requires bucket versioning to be enabled); pass "" for
+// the current version.
+func (s *S3Client) GenerateDownloadURLWithFileNameAndToken(fileName, token string, enc EncryptionOptions, versionID string) (GenerateURLResponse, error) {
 	// ดึง Secret Token จาก Environment Variable
 	secretToken := os.Getenv("SECRET_TOKEN")
 	if secretToken == "" {
@@ -200,19 +300,61 @@ func (s *S3Client) GenerateDownloadURLWithFileNameAndToken(fileName, token strin
 		return GenerateURLResponse{Status: http.StatusUnauthorized}, errors.New("invalid token")
 	}
 
-	// สร้าง Presigned URL ใหม่
+	// ตรวจสอบว่า Token ยังไม่หมดอายุใน TokenStore
+	_, found, err := s.tokenStore.Get(token)
+	if err == ErrTokenExpired {
+		return GenerateURLResponse{Status: http.StatusGone}, errors.New("token expired")
+	}
+	if err != nil {
+		return GenerateURLResponse{Status: http.StatusInternalServerError}, err
+	}
+	if !found {
+		return GenerateURLResponse{Status: http.StatusUnauthorized}, errors.New("invalid token")
+	}
+
+	sseHeaders, err := enc.headers()
+	if err != nil {
+		return GenerateURLResponse{Status: http.StatusBadRequest}, err
+	}
+
+	var reqParams url.Values
+	if versionID != "" {
+		reqParams = url.Values{}
+		reqParams.Set("versionId", versionID)
+	}
+
+	// สร้าง Presigned URL ใหม่; sseHeaders must be signed into the request
+	// (not just handed back alongside it), or S3 rejects the GET with
+	// SignatureDoesNotMatch for SSE-C objects.
 	expirationTime := time.Hour
-	presignedURL, err := s.Client.PresignedGetObject(context.Background(), s.BucketName, fileName, expirationTime, nil)
+	presignedURL, err := s.presignGetURL(context.Background(), fileName, expirationTime, reqParams, sseHeaders)
 	if err != nil {
 		return GenerateURLResponse{Status: http.StatusInternalServerError}, err
 	}
 
 	return GenerateURLResponse{
-		Status: http.StatusOK,
-		URL:    presignedURL.String(),
+		Status:  http.StatusOK,
+		URL:     presignedURL.String(),
+		Headers: sseHeaders,
 	}, nil
 }
 
+// presignGetURL presigns a GET for objectName, signing headers into the
+// request so S3 accepts them. Plain PresignedGetObject only signs the URL;
+// for SSE-C downloads the x-amz-server-side-encryption-customer-* headers
+// must themselves be part of the signature, or S3 returns
+// SignatureDoesNotMatch when the caller attaches them afterwards.
+func (s *S3Client) presignGetURL(ctx context.Context, objectName string, expires time.Duration, reqParams url.Values, headers map[string]string) (*url.URL, error) {
+	var extraHeaders http.Header
+	if len(headers) > 0 {
+		extraHeaders = make(http.Header, len(headers))
+		for k, v := range headers {
+			extraHeaders.Set(k, v)
+		}
+	}
+	return s.Client.PresignHeader(ctx, http.MethodGet, s.BucketName, objectName, expires, reqParams, extraHeaders)
+}
+
 func generateFileID() (string, error) {
 	bytes := make([]byte, 16)
 	if _, err := rand.Read(bytes); err != nil {
@@ -221,9 +363,53 @@ func generateFileID() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-func (s *S3Client) DeleteFile(fileName string) (int, error) {
+// DeleteFile removes fileName from the bucket. token must be the caller's
+// validated download token for fileName so the corresponding entry can be
+// removed from the TokenStore; an already-expired-but-still-recorded token
+// is rejected with http.StatusGone instead of being allowed to delete the
+// file.
+//
+// Once a token's TokenStore entry is gone entirely - because it expired and
+// was purged/evicted (Redis evicts on its own TTL, so this isn't just a
+// MemoryTokenStore/BoltTokenStore race), or because fileName was never
+// issued a download token in the first place (e.g. it was uploaded through
+// the multipart path) - there is no longer any record to report 410 from.
+// The HMAC check above already proves the caller knows SECRET_TOKEN for
+// this exact fileName, so DeleteFile falls back to whether the object still
+// exists in the bucket rather than permanently refusing to delete it.
+func (s *S3Client) DeleteFile(fileName, token string) (int, error) {
+	secretToken := os.Getenv("SECRET_TOKEN")
+	if secretToken == "" {
+		return http.StatusInternalServerError, errors.New("missing SECRET_TOKEN")
+	}
+
+	expectedToken, err := GenerateToken(secretToken, fileName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if token != expectedToken {
+		return http.StatusUnauthorized, errors.New("invalid token")
+	}
+
+	_, found, err := s.tokenStore.Get(token)
+	if err == ErrTokenExpired {
+		return http.StatusGone, errors.New("token expired")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !found {
+		exists, err := s.objectExists(fileName)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to check object existence: %w", err)
+		}
+		if !exists {
+			return http.StatusNotFound, fmt.Errorf("file not found: %s", fileName)
+		}
+	}
+
 	// 1. ลบไฟล์จาก S3 bucket
-	err := s.Client.RemoveObject(context.Background(), s.BucketName, fileName, minio.RemoveObjectOptions{})
+	err = s.Client.RemoveObject(context.Background(), s.BucketName, fileName, minio.RemoveObjectOptions{})
 	if err != nil {
 		// ตรวจสอบ Error เฉพาะของ MinIO
 		if minioErr, ok := err.(minio.ErrorResponse); ok {
@@ -234,13 +420,27 @@ func (s *S3Client) DeleteFile(fileName string) (int, error) {
 		return http.StatusInternalServerError, fmt.Errorf("failed to delete file from S3: %w", err)
 	}
 
-	// 2. ลบ Token ที่เกี่ยวข้องออกจาก tokenMap
-	for token, fileInfo := range s.tokenMap {
-		if fileInfo.FileName == fileName {
-			delete(s.tokenMap, token)
-			break // หยุด loop เมื่อเจอ Token ที่ตรงกัน
-		}
+	// 2. ลบ Token ที่เกี่ยวข้องออกจาก TokenStore, if one was actually found
+	if !found {
+		return http.StatusOK, nil
+	}
+	if err := s.tokenStore.Delete(token); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to delete token: %w", err)
 	}
 
 	return http.StatusOK, nil // ส่งกลับ status 200 OK หากลบไฟล์สำเร็จ
 }
+
+// objectExists reports whether fileName exists in the bucket, used by
+// DeleteFile to decide delete-eligibility when there is no TokenStore record
+// to consult.
+func (s *S3Client) objectExists(fileName string) (bool, error) {
+	_, err := s.Client.StatObject(context.Background(), s.BucketName, fileName, minio.StatObjectOptions{})
+	if err != nil {
+		if minioErr, ok := err.(minio.ErrorResponse); ok && minioErr.Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"context"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// LifecycleRule is a simplified view over minio-go's lifecycle.Rule,
+// covering the two transitions this service needs: expiring objects under a
+// prefix after N days, and moving them to a cheaper storage class after N
+// days.
+type LifecycleRule struct {
+	ID                     string
+	Prefix                 string
+	ExpireAfterDays        int    // 0 disables expiry for this rule
+	TransitionAfterDays    int    // 0 disables transition for this rule
+	TransitionStorageClass string // e.g. "STANDARD_IA", required if TransitionAfterDays > 0
+}
+
+func (r LifecycleRule) toMinioRule() lifecycle.Rule {
+	rule := lifecycle.Rule{
+		ID:         r.ID,
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: r.Prefix},
+	}
+	if r.ExpireAfterDays > 0 {
+		rule.Expiration = lifecycle.Expiration{Days: lifecycle.ExpirationDays(r.ExpireAfterDays)}
+	}
+	if r.TransitionAfterDays > 0 {
+		rule.Transition = lifecycle.Transition{
+			Days:         lifecycle.ExpirationDays(r.TransitionAfterDays),
+			StorageClass: r.TransitionStorageClass,
+		}
+	}
+	return rule
+}
+
+func lifecycleRuleFromMinio(rule lifecycle.Rule) LifecycleRule {
+	return LifecycleRule{
+		ID:                     rule.ID,
+		Prefix:                 rule.RuleFilter.Prefix,
+		ExpireAfterDays:        int(rule.Expiration.Days),
+		TransitionAfterDays:    int(rule.Transition.Days),
+		TransitionStorageClass: rule.Transition.StorageClass,
+	}
+}
+
+// SetLifecycle replaces the bucket's lifecycle configuration with rules.
+func (s *S3Client) SetLifecycle(rules []LifecycleRule) error {
+	cfg := lifecycle.NewConfiguration()
+	for _, r := range rules {
+		cfg.Rules = append(cfg.Rules, r.toMinioRule())
+	}
+	return s.Client.SetBucketLifecycle(context.Background(), s.BucketName, cfg)
+}
+
+// GetLifecycle returns the bucket's current lifecycle rules.
+func (s *S3Client) GetLifecycle() ([]LifecycleRule, error) {
+	cfg, err := s.Client.GetBucketLifecycle(context.Background(), s.BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]LifecycleRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rules = append(rules, lifecycleRuleFromMinio(rule))
+	}
+	return rules, nil
+}
+
+// RemoveLifecycle deletes the bucket's lifecycle configuration entirely.
+func (s *S3Client) RemoveLifecycle() error {
+	return s.Client.RemoveBucketLifecycle(context.Background(), s.BucketName)
+}
+
+// EnableVersioning turns on object versioning for the bucket.
+func (s *S3Client) EnableVersioning() error {
+	return s.Client.EnableVersioning(context.Background(), s.BucketName)
+}
+
+// SuspendVersioning turns off object versioning for the bucket. Existing
+// versions are kept; new writes stop creating additional ones.
+func (s *S3Client) SuspendVersioning() error {
+	return s.Client.SuspendVersioning(context.Background(), s.BucketName)
+}
+
+// ListObjectVersions lists every version of every object under prefix.
+func (s *S3Client) ListObjectVersions(prefix string) ([]minio.ObjectInfo, error) {
+	var versions []minio.ObjectInfo
+
+	for obj := range s.Client.ListObjects(context.Background(), s.BucketName, minio.ListObjectsOptions{
+		Prefix:       prefix,
+		Recursive:    true,
+		WithVersions: true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		versions = append(versions, obj)
+	}
+
+	return versions, nil
+}
+
+// PutObjectTags attaches searchable key/value metadata to objectName.
+func (s *S3Client) PutObjectTags(objectName string, tagMap map[string]string) error {
+	objectTags, err := tags.MapToObjectTags(tagMap)
+	if err != nil {
+		return err
+	}
+	return s.Client.PutObjectTagging(context.Background(), s.BucketName, objectName, objectTags, minio.PutObjectTaggingOptions{})
+}
+
+// GetObjectTags returns the tags currently attached to objectName.
+func (s *S3Client) GetObjectTags(objectName string) (map[string]string, error) {
+	objectTags, err := s.Client.GetObjectTagging(context.Background(), s.BucketName, objectName, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return objectTags.ToMap(), nil
+}
+
+// RemoveObjectTags removes every tag attached to objectName.
+func (s *S3Client) RemoveObjectTags(objectName string) error {
+	return s.Client.RemoveObjectTagging(context.Background(), s.BucketName, objectName, minio.RemoveObjectTaggingOptions{})
+}
+
+// tagPolicy stores the default tags applied to uploads that don't specify
+// their own, the same way encryptionPolicy does for SetDefaultEncryptionPolicy.
+type tagPolicy struct {
+	mu   sync.RWMutex
+	tags map[string]string
+}
+
+// defaultTagsStoreKey is the reserved TokenStore key SetDefaultTags persists
+// the default tag set under, so it survives a process restart instead of
+// living only in the in-memory tagPolicy cache. NewS3Client reloads it from
+// here on startup.
+const defaultTagsStoreKey = "__default_tags__"
+
+// SetDefaultTags sets the tags applied to uploads that pass a nil/empty tag
+// map to UploadMultipleFilesFromStream, and persists them to the TokenStore
+// so the default survives a restart.
+func (s *S3Client) SetDefaultTags(tagMap map[string]string) error {
+	s.defaultTags.mu.Lock()
+	s.defaultTags.tags = tagMap
+	s.defaultTags.mu.Unlock()
+
+	return s.tokenStore.Put(defaultTagsStoreKey, FileInfo{Tags: tagMap})
+}
+
+// GetDefaultTags returns the bucket's current default tag set.
+func (s *S3Client) GetDefaultTags() map[string]string {
+	s.defaultTags.mu.RLock()
+	defer s.defaultTags.mu.RUnlock()
+	return s.defaultTags.tags
+}
+
+// resolveTags returns tagMap unless it's empty, in which case it falls back
+// to the bucket's default tags so a re-upload without explicit tags inherits
+// whatever tags the most recent upload used (see UploadMultipleFilesFromStream).
+func (s *S3Client) resolveTags(tagMap map[string]string) map[string]string {
+	if len(tagMap) > 0 {
+		return tagMap
+	}
+	return s.GetDefaultTags()
+}
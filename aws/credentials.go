@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// CredentialsProvider wraps a minio-go credentials.Credentials value so
+// NewS3Client isn't tied to static V4 keys from the environment. Build one
+// with StaticV4Credentials, StaticV2Credentials, IAMCredentials,
+// STSAssumeRoleCredentials, FileCredentials, or ChainCredentials.
+//
+// There is no separate signature-version selector: minio-go bakes the
+// signing scheme into the credentials.Value a provider returns (e.g.
+// NewStaticV2 tags its Value with the legacy SigV2 signer), so the signer
+// actually used is whichever constructor built this provider, not a field
+// on CredentialsProvider itself.
+type CredentialsProvider struct {
+	creds *credentials.Credentials
+}
+
+// Credentials returns the underlying *credentials.Credentials for passing
+// into minio.Options.
+func (p CredentialsProvider) Credentials() *credentials.Credentials {
+	return p.creds
+}
+
+// StaticV4Credentials builds a CredentialsProvider from a fixed access
+// key/secret key pair (and optional session token), signed with SigV4.
+func StaticV4Credentials(accessKeyID, secretAccessKey, sessionToken string) CredentialsProvider {
+	return CredentialsProvider{
+		creds: credentials.NewStaticV4(accessKeyID, secretAccessKey, sessionToken),
+	}
+}
+
+// StaticV2Credentials builds a CredentialsProvider from a fixed access
+// key/secret key pair, signed with the legacy SigV2 scheme required by some
+// S3-compatible services.
+func StaticV2Credentials(accessKeyID, secretAccessKey string) CredentialsProvider {
+	return CredentialsProvider{
+		creds: credentials.NewStaticV2(accessKeyID, secretAccessKey, ""),
+	}
+}
+
+// IAMCredentials builds a CredentialsProvider that fetches temporary
+// credentials from the EC2/ECS/EKS instance metadata service. Pass "" for
+// endpoint to let the SDK auto-detect it.
+func IAMCredentials(endpoint string) CredentialsProvider {
+	return CredentialsProvider{
+		creds: credentials.NewIAM(endpoint),
+	}
+}
+
+// STSAssumeRoleCredentials builds a CredentialsProvider that assumes
+// roleARN via AWS STS, for cross-account access.
+func STSAssumeRoleCredentials(stsEndpoint, accessKeyID, secretAccessKey, roleARN, roleSessionName string) (CredentialsProvider, error) {
+	provider, err := credentials.NewSTSAssumeRole(stsEndpoint, credentials.STSAssumeRoleOptions{
+		AccessKey:       accessKeyID,
+		SecretKey:       secretAccessKey,
+		RoleARN:         roleARN,
+		RoleSessionName: roleSessionName,
+	})
+	if err != nil {
+		return CredentialsProvider{}, err
+	}
+
+	return CredentialsProvider{creds: provider}, nil
+}
+
+// FileCredentials builds a CredentialsProvider from a shared AWS credentials
+// file (e.g. ~/.aws/credentials), reading the given profile.
+func FileCredentials(filename, profile string) CredentialsProvider {
+	return CredentialsProvider{
+		creds: credentials.NewFileAWSCredentials(filename, profile),
+	}
+}
+
+// ChainCredentials tries each provider in order and uses the first one that
+// returns valid credentials, mirroring credentials.NewChainCredentials. Each
+// provider keeps whatever signing scheme it was built with; there is no
+// single signature version for the chain as a whole.
+func ChainCredentials(providers ...CredentialsProvider) CredentialsProvider {
+	chain := make([]credentials.Provider, 0, len(providers))
+	for _, p := range providers {
+		chain = append(chain, &credentialsAdapter{creds: p.creds})
+	}
+
+	return CredentialsProvider{
+		creds: credentials.NewChainCredentials(chain),
+	}
+}
+
+// credentialsAdapter lets an already-built *credentials.Credentials (as
+// returned by the New* helpers above) be reused as a credentials.Provider,
+// so ChainCredentials can compose them.
+type credentialsAdapter struct {
+	creds *credentials.Credentials
+}
+
+func (a *credentialsAdapter) Retrieve() (credentials.Value, error) {
+	return a.creds.Get()
+}
+
+func (a *credentialsAdapter) IsExpired() bool {
+	return a.creds.IsExpired()
+}
@@ -0,0 +1,346 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// Part size bounds mirror what S3-compatible services require for
+// multipart uploads (the last part is exempt from the minimum).
+const (
+	MinPartSize     = 5 * 1024 * 1024
+	MaxPartSize     = 100 * 1024 * 1024
+	DefaultPartSize = 16 * 1024 * 1024
+)
+
+// ErrSessionNotFound is returned when an upload session ID is unknown,
+// already completed, or already aborted.
+var ErrSessionNotFound = errors.New("upload session not found")
+
+// uploadSession tracks one in-progress resumable upload.
+type uploadSession struct {
+	ID         string
+	ObjectName string
+	UploadID   string
+	PartSize   int64
+	Parts      map[int]minio.CompletePart
+	ExpiresAt  time.Time
+}
+
+// CreateSessionResponse is returned from MultipartSession.Create.
+type CreateSessionResponse struct {
+	ID        string    `json:"id"`
+	PartSize  int64     `json:"part_size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionStatus reports which parts of a session have been received so far,
+// so a client can resume an interrupted upload without resending them.
+type SessionStatus struct {
+	ID            string `json:"id"`
+	ObjectName    string `json:"object_name"`
+	ReceivedParts []int  `json:"received_parts"`
+}
+
+// MultipartSession manages resumable, chunked uploads on top of minio-go's
+// core multipart primitives (NewMultipartUpload, PutObjectPart,
+// CompleteMultipartUpload, AbortMultipartUpload). It mirrors the tus
+// resumable-upload protocol: a client creates a session, PATCHes parts to
+// it in any order and any number of times, then completes it once every
+// part has arrived.
+type MultipartSession struct {
+	core       *minio.Core
+	bucketName string
+	tokenStore TokenStore
+
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+
+	sweepTTL time.Duration
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewMultipartSession wraps an existing minio.Core client and starts a
+// background sweep (every hour) that calls ListIncompleteUploads and aborts
+// sessions older than sweepTTL, reclaiming the storage S3 holds for their
+// parts. Pass <= 0 for sweepTTL to default to 24 hours. tokenStore persists
+// each session's UploadID and received part ETags (as a FileInfo keyed by
+// session ID) so a session survives a process restart and GET /uploads/:id
+// can still report its progress.
+func NewMultipartSession(core *minio.Core, bucketName string, sweepTTL time.Duration, tokenStore TokenStore) *MultipartSession {
+	if sweepTTL <= 0 {
+		sweepTTL = 24 * time.Hour
+	}
+
+	m := &MultipartSession{
+		core:       core,
+		bucketName: bucketName,
+		tokenStore: tokenStore,
+		sessions:   make(map[string]*uploadSession),
+		sweepTTL:   sweepTTL,
+		stop:       make(chan struct{}),
+	}
+
+	go m.runSweep(time.Hour)
+
+	return m
+}
+
+// Close stops the background sweep. It is safe to call multiple times.
+func (m *MultipartSession) Close() error {
+	m.stopOnce.Do(func() { close(m.stop) })
+	return nil
+}
+
+func (m *MultipartSession) runSweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sweepExpired()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Create starts a new multipart upload for objectName and returns the
+// session ID the client will PATCH parts to. partSize <= 0 defaults to
+// DefaultPartSize and must otherwise fall within [MinPartSize, MaxPartSize].
+func (m *MultipartSession) Create(ctx context.Context, objectName, contentType string, partSize int64) (CreateSessionResponse, error) {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	if partSize < MinPartSize || partSize > MaxPartSize {
+		return CreateSessionResponse{}, fmt.Errorf("part size must be between %d and %d bytes", MinPartSize, MaxPartSize)
+	}
+
+	uploadID, err := m.core.NewMultipartUpload(ctx, m.bucketName, objectName, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return CreateSessionResponse{}, err
+	}
+
+	session := &uploadSession{
+		ID:         uuid.New().String(),
+		ObjectName: objectName,
+		UploadID:   uploadID,
+		PartSize:   partSize,
+		Parts:      make(map[int]minio.CompletePart),
+		ExpiresAt:  time.Now().Add(m.sweepTTL),
+	}
+
+	if err := m.persist(session, map[int]string{}); err != nil {
+		return CreateSessionResponse{}, fmt.Errorf("failed to persist upload session: %w", err)
+	}
+
+	m.mu.Lock()
+	m.sessions[session.ID] = session
+	m.mu.Unlock()
+
+	return CreateSessionResponse{ID: session.ID, PartSize: partSize, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// persist writes session's UploadID, PartSize, and parts (a snapshot of
+// session.Parts's ETags taken by the caller while holding m.mu) to the
+// TokenStore, keyed by session ID, so the session can be rehydrated by get
+// after a restart.
+func (m *MultipartSession) persist(session *uploadSession, parts map[int]string) error {
+	return m.tokenStore.Put(session.ID, FileInfo{
+		FileName:  session.ObjectName,
+		ExpiredAt: session.ExpiresAt,
+		UploadID:  session.UploadID,
+		PartSize:  session.PartSize,
+		Parts:     parts,
+	})
+}
+
+// get returns the session for id, rehydrating it from the TokenStore if it
+// isn't already in memory (e.g. after a process restart). It double-checks
+// m.sessions after rehydrating so that two concurrent cache misses for the
+// same id converge on one *uploadSession instead of each installing its own
+// and clobbering the other's part ETags.
+func (m *MultipartSession) get(id string) (*uploadSession, error) {
+	m.mu.Lock()
+	session, ok := m.sessions[id]
+	m.mu.Unlock()
+	if ok {
+		return session, nil
+	}
+
+	info, found, err := m.tokenStore.Get(id)
+	if err == ErrTokenExpired || (err == nil && !found) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make(map[int]minio.CompletePart, len(info.Parts))
+	for partNumber, etag := range info.Parts {
+		parts[partNumber] = minio.CompletePart{PartNumber: partNumber, ETag: etag}
+	}
+
+	rehydrated := &uploadSession{
+		ID:         id,
+		ObjectName: info.FileName,
+		UploadID:   info.UploadID,
+		PartSize:   info.PartSize,
+		Parts:      parts,
+		ExpiresAt:  info.ExpiredAt,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if session, ok := m.sessions[id]; ok {
+		return session, nil
+	}
+	m.sessions[id] = rehydrated
+	return rehydrated, nil
+}
+
+// PutPart uploads part number partNumber (1-based) for session id, storing
+// the ETag S3 returns so Complete can later assemble the object.
+func (m *MultipartSession) PutPart(ctx context.Context, id string, partNumber int, reader io.Reader, size int64) error {
+	session, err := m.get(id)
+	if err != nil {
+		return err
+	}
+
+	part, err := m.core.PutObjectPart(ctx, m.bucketName, session.ObjectName, session.UploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	session.Parts[partNumber] = minio.CompletePart{PartNumber: partNumber, ETag: part.ETag}
+	parts := make(map[int]string, len(session.Parts))
+	for pn, p := range session.Parts {
+		parts[pn] = p.ETag
+	}
+	m.mu.Unlock()
+
+	if err := m.persist(session, parts); err != nil {
+		return fmt.Errorf("failed to persist part %d: %w", partNumber, err)
+	}
+
+	return nil
+}
+
+// Status returns which parts have already been received, so a client can
+// resume an interrupted upload by only sending the parts that are missing.
+func (m *MultipartSession) Status(id string) (SessionStatus, error) {
+	session, err := m.get(id)
+	if err != nil {
+		return SessionStatus{}, err
+	}
+
+	m.mu.Lock()
+	received := make([]int, 0, len(session.Parts))
+	for partNumber := range session.Parts {
+		received = append(received, partNumber)
+	}
+	m.mu.Unlock()
+
+	sort.Ints(received)
+
+	return SessionStatus{ID: session.ID, ObjectName: session.ObjectName, ReceivedParts: received}, nil
+}
+
+// Complete asks S3 to assemble the received parts into the final object and
+// forgets the session.
+func (m *MultipartSession) Complete(ctx context.Context, id string) error {
+	session, err := m.get(id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	parts := make([]minio.CompletePart, 0, len(session.Parts))
+	for _, part := range session.Parts {
+		parts = append(parts, part)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	if _, err := m.core.CompleteMultipartUpload(ctx, m.bucketName, session.ObjectName, session.UploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	if err := m.tokenStore.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete upload session: %w", err)
+	}
+
+	return nil
+}
+
+// Abort cancels a session and discards any parts already uploaded for it.
+func (m *MultipartSession) Abort(ctx context.Context, id string) error {
+	session, err := m.get(id)
+	if err != nil {
+		return err
+	}
+
+	if err := m.core.AbortMultipartUpload(ctx, m.bucketName, session.ObjectName, session.UploadID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	if err := m.tokenStore.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete upload session: %w", err)
+	}
+
+	return nil
+}
+
+// sweepExpired aborts sessions we're tracking that are past their TTL, then
+// reconciles against ListIncompleteUploads to catch multipart uploads S3
+// still considers incomplete but whose in-memory session was lost, e.g.
+// after a restart.
+func (m *MultipartSession) sweepExpired() {
+	now := time.Now()
+
+	m.mu.Lock()
+	var expired []*uploadSession
+	for id, session := range m.sessions {
+		if now.After(session.ExpiresAt) {
+			expired = append(expired, session)
+			delete(m.sessions, id)
+		}
+	}
+	m.mu.Unlock()
+
+	ctx := context.Background()
+	for _, session := range expired {
+		_ = m.core.AbortMultipartUpload(ctx, m.bucketName, session.ObjectName, session.UploadID)
+		_ = m.tokenStore.Delete(session.ID)
+	}
+
+	for info := range m.core.ListIncompleteUploads(ctx, m.bucketName, "", true) {
+		if info.Err != nil {
+			continue
+		}
+		if now.Sub(info.Initiated) < m.sweepTTL {
+			continue
+		}
+		_ = m.core.AbortMultipartUpload(ctx, m.bucketName, info.Key, info.UploadID)
+	}
+}
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenExpired is returned by a TokenStore when a token was found but its
+// FileInfo.ExpiredAt has already passed.
+var ErrTokenExpired = errors.New("token expired")
+
+// TokenStore persists download tokens and their associated FileInfo so that
+// expiration survives process restarts and is actually enforced. NewS3Client
+// defaults to NewMemoryTokenStore, but any implementation (BoltDB, Redis, ...)
+// can be swapped in.
+type TokenStore interface {
+	Put(token string, info FileInfo) error
+	// Get returns the FileInfo for token. If the token exists but is expired,
+	// it returns ErrTokenExpired. If it does not exist, found is false.
+	Get(token string) (info FileInfo, found bool, err error)
+	Delete(token string) error
+	// PurgeExpired removes every entry whose ExpiredAt has passed.
+	PurgeExpired() error
+}
+
+// MemoryTokenStore is an in-memory TokenStore guarded by a mutex. It is the
+// default used by NewS3Client and runs a background janitor goroutine that
+// periodically calls PurgeExpired so expired tokens don't linger forever.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]FileInfo
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewMemoryTokenStore creates a MemoryTokenStore and starts its janitor
+// goroutine, which calls PurgeExpired every janitorInterval. If
+// janitorInterval is <= 0, it defaults to time.Minute.
+func NewMemoryTokenStore(janitorInterval time.Duration) *MemoryTokenStore {
+	if janitorInterval <= 0 {
+		janitorInterval = time.Minute
+	}
+
+	s := &MemoryTokenStore{
+		tokens: make(map[string]FileInfo),
+		stop:   make(chan struct{}),
+	}
+
+	go s.runJanitor(janitorInterval)
+
+	return s
+}
+
+func (s *MemoryTokenStore) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.PurgeExpired()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Close stops the janitor goroutine. It is safe to call multiple times.
+func (s *MemoryTokenStore) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	return nil
+}
+
+func (s *MemoryTokenStore) Put(token string, info FileInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = info
+	return nil
+}
+
+func (s *MemoryTokenStore) Get(token string) (FileInfo, bool, error) {
+	s.mu.RLock()
+	info, ok := s.tokens[token]
+	s.mu.RUnlock()
+
+	if !ok {
+		return FileInfo{}, false, nil
+	}
+	if !info.ExpiredAt.IsZero() && time.Now().After(info.ExpiredAt) {
+		return info, true, ErrTokenExpired
+	}
+	return info, true, nil
+}
+
+func (s *MemoryTokenStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, token)
+	return nil
+}
+
+func (s *MemoryTokenStore) PurgeExpired() error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for token, info := range s.tokens {
+		if !info.ExpiredAt.IsZero() && now.After(info.ExpiredAt) {
+			delete(s.tokens, token)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var tokensBucket = []byte("tokens")
+
+// BoltTokenStore is a TokenStore backed by a local BoltDB file. It is a
+// drop-in replacement for MemoryTokenStore for single-instance deployments
+// that need tokens to survive a restart without standing up Redis.
+type BoltTokenStore struct {
+	db *bolt.DB
+}
+
+// NewBoltTokenStore opens (creating if necessary) a BoltDB file at path and
+// ensures the tokens bucket exists.
+func NewBoltTokenStore(path string) (*BoltTokenStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(tokensBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltTokenStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltTokenStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltTokenStore) Put(token string, info FileInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tokensBucket).Put([]byte(token), data)
+	})
+}
+
+func (s *BoltTokenStore) Get(token string) (FileInfo, bool, error) {
+	var info FileInfo
+	found := false
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(tokensBucket).Get([]byte(token))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &info)
+	})
+	if err != nil {
+		return FileInfo{}, false, err
+	}
+	if !found {
+		return FileInfo{}, false, nil
+	}
+	if !info.ExpiredAt.IsZero() && time.Now().After(info.ExpiredAt) {
+		return info, true, ErrTokenExpired
+	}
+	return info, true, nil
+}
+
+func (s *BoltTokenStore) Delete(token string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tokensBucket).Delete([]byte(token))
+	})
+}
+
+func (s *BoltTokenStore) PurgeExpired() error {
+	now := time.Now()
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(tokensBucket)
+		c := b.Cursor()
+
+		var expiredKeys [][]byte
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var info FileInfo
+			if err := json.Unmarshal(v, &info); err != nil {
+				continue
+			}
+			if !info.ExpiredAt.IsZero() && now.After(info.ExpiredAt) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range expiredKeys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltTokenStore_RoundTrip_DefaultTagsZeroExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.db")
+
+	store, err := NewBoltTokenStore(path)
+	if err != nil {
+		t.Fatalf("NewBoltTokenStore: %v", err)
+	}
+	defer store.Close()
+
+	// Mirrors how SetDefaultTags persists the default-tags entry: no
+	// FileName/ExpiredAt, just the tag map, so it should never expire.
+	info := FileInfo{Tags: map[string]string{"env": "prod"}}
+	if err := store.Put(defaultTagsStoreKey, info); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, found, err := store.Get(defaultTagsStoreKey)
+	if err != nil {
+		t.Fatalf("Get err = %v, want nil", err)
+	}
+	if !found {
+		t.Fatal("Get found = false, want true")
+	}
+	if got.Tags["env"] != "prod" {
+		t.Fatalf("Get Tags = %v, want env=prod", got.Tags)
+	}
+	if !got.ExpiredAt.IsZero() {
+		t.Fatalf("Get ExpiredAt = %v, want zero value", got.ExpiredAt)
+	}
+}
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTokenStore is a TokenStore backed by Redis. Expiration is enforced
+// both by Redis' own key TTL (so PurgeExpired is a no-op)
+// and by the same ExpiredAt check the other implementations use, in case a
+// token was written with a past ExpiredAt.
+type RedisTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisTokenStore wraps an existing redis.Client. Keys are stored under
+// prefix+token; pass "" for prefix to use "token:".
+func NewRedisTokenStore(client *redis.Client, prefix string) *RedisTokenStore {
+	if prefix == "" {
+		prefix = "token:"
+	}
+	return &RedisTokenStore{client: client, prefix: prefix}
+}
+
+func (s *RedisTokenStore) key(token string) string {
+	return s.prefix + token
+}
+
+func (s *RedisTokenStore) Put(token string, info FileInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(0)
+	if !info.ExpiredAt.IsZero() {
+		if remaining := time.Until(info.ExpiredAt); remaining > 0 {
+			ttl = remaining
+		} else {
+			ttl = time.Millisecond
+		}
+	}
+
+	return s.client.Set(context.Background(), s.key(token), data, ttl).Err()
+}
+
+func (s *RedisTokenStore) Get(token string) (FileInfo, bool, error) {
+	data, err := s.client.Get(context.Background(), s.key(token)).Bytes()
+	if err == redis.Nil {
+		return FileInfo{}, false, nil
+	}
+	if err != nil {
+		return FileInfo{}, false, err
+	}
+
+	var info FileInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return FileInfo{}, false, err
+	}
+	if !info.ExpiredAt.IsZero() && time.Now().After(info.ExpiredAt) {
+		return info, true, ErrTokenExpired
+	}
+	return info, true, nil
+}
+
+func (s *RedisTokenStore) Delete(token string) error {
+	return s.client.Del(context.Background(), s.key(token)).Err()
+}
+
+// PurgeExpired is a no-op: Redis already evicts keys once their TTL elapses.
+func (s *RedisTokenStore) PurgeExpired() error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenStore_Get_Expired(t *testing.T) {
+	store := NewMemoryTokenStore(time.Hour) // keep the janitor from racing the test
+	defer store.Close()
+
+	info := FileInfo{FileName: "expired.txt", ExpiredAt: time.Now().Add(-time.Minute)}
+	if err := store.Put("tok", info); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, found, err := store.Get("tok")
+	if err != ErrTokenExpired {
+		t.Fatalf("Get err = %v, want ErrTokenExpired", err)
+	}
+	if !found {
+		t.Fatal("Get found = false, want true for an expired-but-present entry")
+	}
+	if got.FileName != info.FileName {
+		t.Fatalf("Get FileName = %q, want %q", got.FileName, info.FileName)
+	}
+}
+
+func TestMemoryTokenStore_Get_NotExpired(t *testing.T) {
+	store := NewMemoryTokenStore(time.Hour)
+	defer store.Close()
+
+	info := FileInfo{FileName: "fresh.txt", ExpiredAt: time.Now().Add(time.Hour)}
+	if err := store.Put("tok", info); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	_, found, err := store.Get("tok")
+	if err != nil {
+		t.Fatalf("Get err = %v, want nil", err)
+	}
+	if !found {
+		t.Fatal("Get found = false, want true")
+	}
+}
+
+func TestMemoryTokenStore_Get_NotFound(t *testing.T) {
+	store := NewMemoryTokenStore(time.Hour)
+	defer store.Close()
+
+	_, found, err := store.Get("missing")
+	if err != nil {
+		t.Fatalf("Get err = %v, want nil", err)
+	}
+	if found {
+		t.Fatal("Get found = true, want false for an unknown token")
+	}
+}
@@ -0,0 +1,308 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// BucketEvent is the payload delivered to webhook subscribers and to
+// /events/stream SSE clients for every S3-compatible bucket notification.
+type BucketEvent struct {
+	EventName string    `json:"event_name"`
+	Key       string    `json:"key"`
+	Time      time.Time `json:"time"`
+}
+
+// WebhookSubscription is a registered webhook: a target URL plus an event
+// filter (s3:ObjectCreated:*, s3:ObjectRemoved:*, s3:ObjectAccessed:*) and
+// an optional key prefix/suffix.
+type WebhookSubscription struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Prefix string   `json:"prefix"`
+	Suffix string   `json:"suffix"`
+}
+
+func (s WebhookSubscription) matches(event BucketEvent) bool {
+	if s.Prefix != "" && !strings.HasPrefix(event.Key, s.Prefix) {
+		return false
+	}
+	if s.Suffix != "" && !strings.HasSuffix(event.Key, s.Suffix) {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, want := range s.Events {
+		if eventNameMatches(want, event.EventName) {
+			return true
+		}
+	}
+	return false
+}
+
+func eventNameMatches(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == name
+}
+
+const (
+	webhookQueueSize  = 100
+	webhookMaxRetries = 5
+)
+
+type subscriberQueue struct {
+	sub   WebhookSubscription
+	queue chan BucketEvent
+}
+
+// WebhookDispatcher streams S3 bucket notifications via
+// ListenBucketNotification and fans them out to registered webhook URLs
+// (HMAC-signed using GenerateToken) and to any listeners of /events/stream.
+type WebhookDispatcher struct {
+	client     *minio.Client
+	bucketName string
+	httpClient *http.Client
+
+	mu          sync.RWMutex
+	subscribers map[string]*subscriberQueue
+
+	sseMu   sync.Mutex
+	sseSubs map[chan BucketEvent]struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewWebhookDispatcher starts listening for bucket notifications in the
+// background. Call Close to stop.
+func NewWebhookDispatcher(client *minio.Client, bucketName string) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		client:      client,
+		bucketName:  bucketName,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		subscribers: make(map[string]*subscriberQueue),
+		sseSubs:     make(map[chan BucketEvent]struct{}),
+		stop:        make(chan struct{}),
+	}
+
+	go d.listen()
+
+	return d
+}
+
+// Close stops the notification listener and every webhook delivery worker.
+func (d *WebhookDispatcher) Close() error {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+
+		d.mu.Lock()
+		for id, sq := range d.subscribers {
+			delete(d.subscribers, id)
+			close(sq.queue)
+		}
+		d.mu.Unlock()
+	})
+	return nil
+}
+
+// listen consumes ListenBucketNotification, reconnecting with a short
+// backoff if the stream ends (e.g. the connection dropped).
+func (d *WebhookDispatcher) listen() {
+	events := []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*", "s3:ObjectAccessed:*"}
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		notificationCh := d.client.ListenBucketNotification(ctx, d.bucketName, "", "", events)
+
+	drain:
+		for {
+			select {
+			case notification, ok := <-notificationCh:
+				if !ok {
+					break drain
+				}
+				if notification.Err != nil {
+					log.Printf("bucket notification error: %v", notification.Err)
+					continue
+				}
+				for _, record := range notification.Records {
+					d.dispatch(BucketEvent{
+						EventName: record.EventName,
+						Key:       record.S3.Object.Key,
+						Time:      time.Now(),
+					})
+				}
+			case <-d.stop:
+				cancel()
+				return
+			}
+		}
+
+		cancel()
+		time.Sleep(time.Second)
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(event BucketEvent) {
+	d.broadcastSSE(event)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, sq := range d.subscribers {
+		if !sq.sub.matches(event) {
+			continue
+		}
+		select {
+		case sq.queue <- event:
+		default:
+			log.Printf("webhook %s: queue full, dropping event for %s", sq.sub.ID, event.Key)
+		}
+	}
+}
+
+// Subscribe registers a webhook and starts its delivery worker.
+func (d *WebhookDispatcher) Subscribe(sub WebhookSubscription) WebhookSubscription {
+	sub.ID = uuid.New().String()
+	sq := &subscriberQueue{sub: sub, queue: make(chan BucketEvent, webhookQueueSize)}
+
+	d.mu.Lock()
+	d.subscribers[sub.ID] = sq
+	d.mu.Unlock()
+
+	go d.deliverLoop(sq)
+
+	return sub
+}
+
+// Unsubscribe removes a webhook subscription by ID, returning false if it
+// wasn't found.
+func (d *WebhookDispatcher) Unsubscribe(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sq, ok := d.subscribers[id]
+	if !ok {
+		return false
+	}
+	delete(d.subscribers, id)
+	close(sq.queue)
+	return true
+}
+
+// List returns every registered webhook subscription.
+func (d *WebhookDispatcher) List() []WebhookSubscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	subs := make([]WebhookSubscription, 0, len(d.subscribers))
+	for _, sq := range d.subscribers {
+		subs = append(subs, sq.sub)
+	}
+	return subs
+}
+
+func (d *WebhookDispatcher) deliverLoop(sq *subscriberQueue) {
+	for event := range sq.queue {
+		d.deliverWithRetry(sq.sub, event)
+	}
+}
+
+// deliverWithRetry POSTs event to sub.URL, retrying with exponential backoff
+// and making at most webhookMaxRetries attempts in total before giving up on
+// that event.
+func (d *WebhookDispatcher) deliverWithRetry(sub WebhookSubscription, event BucketEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhook %s: failed to marshal event: %v", sub.ID, err)
+		return
+	}
+
+	signature, err := GenerateToken(os.Getenv("SECRET_TOKEN"), string(payload))
+	if err != nil {
+		log.Printf("webhook %s: failed to sign event: %v", sub.ID, err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 0; attempt < webhookMaxRetries; attempt++ {
+		if d.deliverOnce(sub, payload, signature) {
+			return
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	log.Printf("webhook %s: giving up delivering event for %s after %d attempts", sub.ID, event.Key, webhookMaxRetries)
+}
+
+func (d *WebhookDispatcher) deliverOnce(sub WebhookSubscription, payload []byte, signature string) bool {
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature", signature)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode < 300
+}
+
+// SubscribeSSE registers a new channel that receives every bucket event
+// until UnsubscribeSSE is called; used by the /events/stream endpoint.
+func (d *WebhookDispatcher) SubscribeSSE() chan BucketEvent {
+	ch := make(chan BucketEvent, webhookQueueSize)
+
+	d.sseMu.Lock()
+	d.sseSubs[ch] = struct{}{}
+	d.sseMu.Unlock()
+
+	return ch
+}
+
+// UnsubscribeSSE removes and closes a channel returned by SubscribeSSE.
+func (d *WebhookDispatcher) UnsubscribeSSE(ch chan BucketEvent) {
+	d.sseMu.Lock()
+	delete(d.sseSubs, ch)
+	d.sseMu.Unlock()
+
+	close(ch)
+}
+
+func (d *WebhookDispatcher) broadcastSSE(event BucketEvent) {
+	d.sseMu.Lock()
+	defer d.sseMu.Unlock()
+
+	for ch := range d.sseSubs {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("SSE subscriber queue full, dropping event for %s", event.Key)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package aws
+
+import "testing"
+
+func TestWebhookSubscription_Matches(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  WebhookSubscription
+		evt  BucketEvent
+		want bool
+	}{
+		{
+			name: "no filters matches everything",
+			sub:  WebhookSubscription{},
+			evt:  BucketEvent{EventName: "s3:ObjectCreated:Put", Key: "uploads/a.txt"},
+			want: true,
+		},
+		{
+			name: "event wildcard matches",
+			sub:  WebhookSubscription{Events: []string{"s3:ObjectCreated:*"}},
+			evt:  BucketEvent{EventName: "s3:ObjectCreated:Put", Key: "a.txt"},
+			want: true,
+		},
+		{
+			name: "event name mismatch",
+			sub:  WebhookSubscription{Events: []string{"s3:ObjectRemoved:*"}},
+			evt:  BucketEvent{EventName: "s3:ObjectCreated:Put", Key: "a.txt"},
+			want: false,
+		},
+		{
+			name: "prefix mismatch",
+			sub:  WebhookSubscription{Prefix: "uploads/"},
+			evt:  BucketEvent{EventName: "s3:ObjectCreated:Put", Key: "other/a.txt"},
+			want: false,
+		},
+		{
+			name: "prefix and suffix match",
+			sub:  WebhookSubscription{Prefix: "uploads/", Suffix: ".txt"},
+			evt:  BucketEvent{EventName: "s3:ObjectCreated:Put", Key: "uploads/a.txt"},
+			want: true,
+		},
+		{
+			name: "suffix mismatch",
+			sub:  WebhookSubscription{Suffix: ".png"},
+			evt:  BucketEvent{EventName: "s3:ObjectCreated:Put", Key: "uploads/a.txt"},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.matches(tt.evt); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,51 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	s3 "bam/aws"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
+	"github.com/valyala/fasthttp"
 )
 
+// parseEncryptionOptions reads the X-Encryption-Mode header (and, depending
+// on the mode, X-SSE-Customer-Key or X-SSE-Kms-Key-Id) so clients can opt
+// into server-side encryption per request instead of always using the
+// bucket's default policy.
+func parseEncryptionOptions(c *fiber.Ctx) (s3.EncryptionOptions, error) {
+	switch c.Get("X-Encryption-Mode") {
+	case "", "none":
+		return s3.EncryptionOptions{}, nil
+	case "SSE-S3":
+		return s3.EncryptionOptions{Mode: s3.EncryptionSSES3}, nil
+	case "SSE-KMS":
+		keyID := c.Get("X-SSE-Kms-Key-Id")
+		if keyID == "" {
+			return s3.EncryptionOptions{}, errors.New("X-SSE-Kms-Key-Id is required for SSE-KMS")
+		}
+		return s3.EncryptionOptions{Mode: s3.EncryptionSSEKMS, KMSKeyID: keyID}, nil
+	case "SSE-C":
+		customerKey := c.Get("X-SSE-Customer-Key")
+		if customerKey == "" {
+			return s3.EncryptionOptions{}, errors.New("X-SSE-Customer-Key is required for SSE-C")
+		}
+		return s3.EncryptionOptions{Mode: s3.EncryptionSSEC, CustomerKey: []byte(customerKey)}, nil
+	default:
+		return s3.EncryptionOptions{}, errors.New("unknown X-Encryption-Mode")
+	}
+}
+
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -18,13 +53,19 @@ func main() {
 	}
 	// Set up S3 client
 	endpoint := os.Getenv("ENDPOINT") // Use the endpoint specific to your region
-	accessKeyID := os.Getenv("ACCESS_KEY_ID")
-	secretAccessKey := os.Getenv("SECRET_ACCESS_KEY")
 	useSSL := true
 	bucketName := os.Getenv("BUCKET_NAME")
+	region := os.Getenv("REGION")
+
+	// Default to static V4 keys from the environment. On EC2/ECS/EKS, swap
+	// this for s3.IAMCredentials("") instead; s3.ChainCredentials can
+	// combine several for fallback (e.g. static keys, then IAM role).
+	creds := s3.StaticV4Credentials(os.Getenv("ACCESS_KEY_ID"), os.Getenv("SECRET_ACCESS_KEY"), "")
 
-	// Create S3 client instance
-	s3Client, err := s3.NewS3Client(endpoint, accessKeyID, secretAccessKey, bucketName, useSSL)
+	// Create S3 client instance. Passing nil uses the default in-memory
+	// TokenStore; swap in s3.NewBoltTokenStore or s3.NewRedisTokenStore here
+	// to persist tokens across restarts.
+	s3Client, err := s3.NewS3Client(endpoint, creds, region, bucketName, useSSL, nil)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -60,7 +101,31 @@ func main() {
 			return c.Status(fiber.StatusBadRequest).SendString("No files uploaded")
 		}
 
-		uploadResponses, err := s3Client.UploadMultipleFilesFromStream(files, files[0].Header.Get("Content-Type"))
+		// Optional per-upload TTL override via ?ttl=<seconds>, defaults to s3.DefaultTokenTTL
+		var ttl time.Duration
+		if raw := c.Query("ttl"); raw != "" {
+			seconds, err := strconv.Atoi(raw)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).SendString("invalid ttl")
+			}
+			ttl = time.Duration(seconds) * time.Second
+		}
+
+		encOpts, err := parseEncryptionOptions(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+
+		// Optional object tags as a JSON object, e.g. {"project":"bam"}.
+		// Falls back to the bucket's default tags (see SetDefaultTags) when omitted.
+		var objectTags map[string]string
+		if raw := c.FormValue("tags"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &objectTags); err != nil {
+				return c.Status(fiber.StatusBadRequest).SendString("invalid tags JSON")
+			}
+		}
+
+		uploadResponses, err := s3Client.UploadMultipleFilesFromStream(files, files[0].Header.Get("Content-Type"), ttl, encOpts, objectTags)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 		}
@@ -71,8 +136,14 @@ func main() {
 	app.Get("/download/:filename/:token", func(c *fiber.Ctx) error {
 		fileName := c.Params("filename")
 		token := c.Params("token")
+		versionID := c.Query("version")
+
+		encOpts, err := parseEncryptionOptions(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 
-		downloadResponse, err := s3Client.GenerateDownloadURLWithFileNameAndToken(fileName, token)
+		downloadResponse, err := s3Client.GenerateDownloadURLWithFileNameAndToken(fileName, token, encOpts, versionID)
 		if err != nil {
 			// Handle error (e.g., return an error response to the user)
 			return c.Status(downloadResponse.Status).JSON(fiber.Map{"error": err.Error()})
@@ -81,6 +152,214 @@ func main() {
 		return c.JSON(downloadResponse)
 	})
 
+	// Set the bucket-wide default encryption policy applied to uploads that
+	// send no X-Encryption-Mode header or send it as "none".
+	app.Post("/config/encryption", func(c *fiber.Ctx) error {
+		var body struct {
+			Mode     string `json:"mode"`
+			KMSKeyID string `json:"kms_key_id"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		var opts s3.EncryptionOptions
+		switch body.Mode {
+		case "", "none":
+			opts = s3.EncryptionOptions{}
+		case "SSE-S3":
+			opts = s3.EncryptionOptions{Mode: s3.EncryptionSSES3}
+		case "SSE-KMS":
+			if body.KMSKeyID == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "kms_key_id is required for SSE-KMS"})
+			}
+			opts = s3.EncryptionOptions{Mode: s3.EncryptionSSEKMS, KMSKeyID: body.KMSKeyID}
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unsupported default mode (use none, SSE-S3 or SSE-KMS)"})
+		}
+
+		s3Client.SetDefaultEncryptionPolicy(opts)
+		return c.SendString("Default encryption policy updated")
+	})
+
+	// Webhook subscriptions for bucket notifications (s3:ObjectCreated:*,
+	// s3:ObjectRemoved:*, s3:ObjectAccessed:*).
+	app.Post("/webhooks", func(c *fiber.Ctx) error {
+		var sub s3.WebhookSubscription
+		if err := c.BodyParser(&sub); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+		if sub.URL == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "url is required"})
+		}
+
+		return c.JSON(s3Client.Webhooks.Subscribe(sub))
+	})
+
+	app.Get("/webhooks", func(c *fiber.Ctx) error {
+		return c.JSON(s3Client.Webhooks.List())
+	})
+
+	app.Delete("/webhooks/:id", func(c *fiber.Ctx) error {
+		if !s3Client.Webhooks.Unsubscribe(c.Params("id")) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "webhook not found"})
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// SSE stream of bucket notifications for browser clients that want to
+	// consume events directly instead of registering a webhook.
+	app.Get("/events/stream", func(c *fiber.Ctx) error {
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+
+		events := s3Client.Webhooks.SubscribeSSE()
+
+		c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+			defer s3Client.Webhooks.UnsubscribeSSE(events)
+
+			for event := range events {
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}))
+
+		return nil
+	})
+
+	// Bucket lifecycle rules, e.g. "expire objects with prefix tmp/ after 7
+	// days" or "transition to STANDARD_IA after 30 days".
+	app.Post("/bucket/lifecycle", func(c *fiber.Ctx) error {
+		var body struct {
+			Rules []s3.LifecycleRule `json:"rules"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		if err := s3Client.SetLifecycle(body.Rules); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.SendString("Lifecycle configuration updated")
+	})
+
+	app.Get("/bucket/lifecycle", func(c *fiber.Ctx) error {
+		rules, err := s3Client.GetLifecycle()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(rules)
+	})
+
+	app.Delete("/bucket/lifecycle", func(c *fiber.Ctx) error {
+		if err := s3Client.RemoveLifecycle(); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.SendString("Lifecycle configuration removed")
+	})
+
+	// Bucket versioning and per-object-version listing.
+	app.Post("/bucket/versioning/:state", func(c *fiber.Ctx) error {
+		var err error
+		switch c.Params("state") {
+		case "enable":
+			err = s3Client.EnableVersioning()
+		case "suspend":
+			err = s3Client.SuspendVersioning()
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "state must be 'enable' or 'suspend'"})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.SendString("Versioning updated")
+	})
+
+	app.Get("/bucket/versions", func(c *fiber.Ctx) error {
+		versions, err := s3Client.ListObjectVersions(c.Query("prefix"))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(versions)
+	})
+
+	// Resumable multipart upload endpoints: create a session, PATCH parts to
+	// it (in any order, any number of times), poll which parts have arrived,
+	// then complete it once every part is in.
+	app.Post("/uploads", func(c *fiber.Ctx) error {
+		var body struct {
+			FileName    string `json:"file_name"`
+			ContentType string `json:"content_type"`
+			PartSize    int64  `json:"part_size"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+		if body.FileName == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file_name is required"})
+		}
+
+		session, err := s3Client.Multipart.Create(c.Context(), body.FileName, body.ContentType, body.PartSize)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(session)
+	})
+
+	app.Patch("/uploads/:id", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		partNumber, err := strconv.Atoi(c.Query("part"))
+		if err != nil || partNumber < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "part query parameter must be a positive integer"})
+		}
+
+		body := c.Body()
+		if err := s3Client.Multipart.PutPart(c.Context(), id, partNumber, bytes.NewReader(body), int64(len(body))); err != nil {
+			status := fiber.StatusInternalServerError
+			if err == s3.ErrSessionNotFound {
+				status = fiber.StatusNotFound
+			}
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	app.Get("/uploads/:id", func(c *fiber.Ctx) error {
+		status, err := s3Client.Multipart.Status(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(status)
+	})
+
+	app.Post("/uploads/:id/complete", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		if err := s3Client.Multipart.Complete(c.Context(), id); err != nil {
+			status := fiber.StatusInternalServerError
+			if err == s3.ErrSessionNotFound {
+				status = fiber.StatusNotFound
+			}
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.SendString("Upload completed")
+	})
+
 	app.Delete("/delete/:filename/:token", func(c *fiber.Ctx) error {
 		fileName := c.Params("filename")
 		token := c.Params("token")
@@ -106,7 +385,7 @@ func main() {
 		// if err != nil {
 		// 	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		// }
-		status, err := s3Client.DeleteFile(fileName) // รับค่า status ออกมาด้วย
+		status, err := s3Client.DeleteFile(fileName, token) // รับค่า status ออกมาด้วย
 		if err != nil {
 			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
 		}